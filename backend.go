@@ -0,0 +1,11 @@
+package cache
+
+// Backend is a tier a Bytes cache can spill evicted entries into instead of losing them,
+// and promote them back from on a miss. See backend/fs for a filesystem-backed
+// implementation and backend/null for the default (no backend) behavior.
+type Backend interface {
+	Get(id int) ([]byte, bool)
+	Put(id int, p []byte)
+	Remove(id int)
+	Close()
+}
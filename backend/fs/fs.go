@@ -0,0 +1,150 @@
+// Package fs is an on-disk cache.Backend: one file per id under a base directory, kept
+// under a configurable byte budget by the same SIEVE eviction used in cache.Bytes.
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+type entry struct {
+	id int
+	size int64
+	visited int32
+	prev, next *entry
+}
+
+type Backend struct {
+	dir string
+	mem, max int64
+	mutex sync.Mutex
+	entries map[int]*entry
+	head, tail, hand *entry
+}
+
+// Creates a new filesystem backend rooted at dir, evicting the least valuable files once
+// the total size of files it manages exceeds megabytes
+func New(dir string, megabytes int64) *Backend {
+	os.MkdirAll(dir, 0755)
+	return &Backend{dir: dir, max: megabytes * 1048576, entries: make(map[int]*entry)}
+}
+
+func (b *Backend) path(id int) string {
+	return filepath.Join(b.dir, strconv.Itoa(id))
+}
+
+func (b *Backend) Get(id int) ([]byte, bool) {
+	b.mutex.Lock()
+	e, ok := b.entries[id]
+	b.mutex.Unlock()
+	if !ok {
+		return nil, false
+	}
+	p, err := os.ReadFile(b.path(id))
+	if err != nil {
+		return nil, false
+	}
+	atomic.StoreInt32(&e.visited, 1)
+	return p, true
+}
+
+func (b *Backend) Put(id int, p []byte) {
+	if err := os.WriteFile(b.path(id), p, 0644); err != nil {
+		return
+	}
+	b.mutex.Lock()
+	if old, ok := b.entries[id]; ok {
+		memdif := int64(len(p)) - old.size
+		old.size = int64(len(p))
+		b.mutex.Unlock()
+		atomic.AddInt64(&b.mem, memdif)
+	} else {
+		e := &entry{id: id, size: int64(len(p))}
+		b.entries[id] = e
+		b.insert(e)
+		b.mutex.Unlock()
+		atomic.AddInt64(&b.mem, e.size)
+	}
+	b.evict()
+}
+
+func (b *Backend) Remove(id int) {
+	b.mutex.Lock()
+	e, ok := b.entries[id]
+	if ok {
+		delete(b.entries, id)
+		b.unlink(e)
+	}
+	b.mutex.Unlock()
+	if ok {
+		atomic.AddInt64(&b.mem, 0-e.size)
+		os.Remove(b.path(id))
+	}
+}
+
+// Closes the backend, forgetting which files it manages; the files themselves are left on disk
+func (b *Backend) Close() {
+	b.mutex.Lock()
+	b.entries = nil
+	b.head, b.tail, b.hand = nil, nil, nil
+	b.mem = 0
+	b.mutex.Unlock()
+}
+
+// insert adds a freshly written entry to the head of the SIEVE list; caller holds mutex
+func (b *Backend) insert(e *entry) {
+	e.next = b.head
+	e.prev = nil
+	if b.head != nil {
+		b.head.prev = e
+	}
+	b.head = e
+	if b.tail == nil {
+		b.tail = e
+	}
+}
+
+// unlink removes an entry from the SIEVE list; caller holds mutex
+func (b *Backend) unlink(e *entry) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		b.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		b.tail = e.prev
+	}
+	if b.hand == e {
+		b.hand = e.prev
+	}
+}
+
+// evict walks the hand backward until the managed files are back under budget
+func (b *Backend) evict() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for atomic.LoadInt64(&b.mem) > atomic.LoadInt64(&b.max) {
+		node := b.hand
+		if node == nil {
+			node = b.tail
+			if node == nil {
+				return
+			}
+		}
+		if atomic.LoadInt32(&node.visited) == 1 {
+			atomic.StoreInt32(&node.visited, 0)
+			b.hand = node.prev
+			continue
+		}
+		b.hand = node.prev
+		b.unlink(node)
+		delete(b.entries, node.id)
+		atomic.AddInt64(&b.mem, 0-node.size)
+		os.Remove(b.path(node.id))
+	}
+}
@@ -15,38 +15,80 @@ var caches2 []*Interface
 var cachesMutex1 sync.Mutex
 var cachesMutex2 sync.Mutex
 
+// Policy selects the eviction strategy used once a cache exceeds its memory budget.
+type Policy int
+
+const (
+	// PolicyLRU purges by last-access age on a background timer, as this package always has.
+	PolicyLRU Policy = iota
+	// PolicySIEVE evicts inline, using the SIEVE algorithm (FIFO list + single hand + visited bit).
+	PolicySIEVE
+)
+
 type entryBytes struct {
 	mutex sync.Mutex
 	lastAccess int64
+	expiresAt int64
 	data []byte
+	id int
+	visited int32
+	refcount int32
+	marked int32
+	removing int32
+	prev, next *entryBytes
 }
 
 type Bytes struct {
 	vals []*entryBytes
 	mem, max int64
 	size int
+	policy Policy
+	backend Backend
+	listMutex sync.Mutex
+	head, tail, hand *entryBytes
+	hits, misses, evictions, expirations, bytesIn, bytesOut int64
 }
 
 type entryInterface struct {
 	mutex sync.Mutex
 	lastAccess int64
+	expiresAt int64
 	data interface{}
 	size int64
+	id int
+	visited int32
+	refcount int32
+	marked int32
+	removing int32
+	prev, next *entryInterface
 }
 
 type Interface struct {
 	vals []*entryInterface
 	mem, max int64
 	size int
+	policy Policy
+	listMutex sync.Mutex
+	head, tail, hand *entryInterface
+	hits, misses, evictions, expirations, bytesIn, bytesOut int64
 }
 
 func init() {
 	go cleaner()
 }
 
-// Creates a new cache
-func NewBytes(size int, megabytes int64) *Bytes {
-	c := &Bytes{vals: make([]*entryBytes, size), size: size, max: megabytes * 1048576}
+// Creates a new cache using the tiered time-based purge (PolicyLRU). An optional Backend
+// receives entries evicted from memory instead of losing them, and serves them back on a miss.
+func NewBytes(size int, megabytes int64, backend ...Backend) *Bytes {
+	return NewBytesWithPolicy(size, megabytes, PolicyLRU, backend...)
+}
+
+// Creates a new cache using the given eviction policy and an optional Backend
+func NewBytesWithPolicy(size int, megabytes int64, policy Policy, backend ...Backend) *Bytes {
+	c := &Bytes{vals: make([]*entryBytes, size), size: size, max: megabytes * 1048576, policy: policy}
+	if len(backend) > 0 {
+		c.backend = backend[0]
+	}
 	cachesMutex1.Lock()
 	caches1 = append(caches1, c)
 	cachesMutex1.Unlock()
@@ -60,13 +102,33 @@ func (c *Bytes) Get(id int) ([]byte, bool) {
 	}
 	item := c.vals[id]
 	if item == nil {
+		if c.backend != nil {
+			if p, ok := c.backend.Get(id); ok {
+				c.Store(id, p)
+				atomic.AddInt64(&c.hits, 1)
+				atomic.AddInt64(&c.bytesOut, int64(len(p)))
+				return p, true
+			}
+		}
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	if item.expiresAt != 0 && time.Now().Unix() > item.expiresAt {
+		c.dropExpired(id, item)
+		atomic.AddInt64(&c.expirations, 1)
+		atomic.AddInt64(&c.misses, 1)
 		return nil, false
 	}
+	if c.policy == PolicySIEVE {
+		atomic.StoreInt32(&item.visited, 1)
+	}
 	tim := time.Now().Unix()
 	item.mutex.Lock()
 	item.lastAccess = tim
 	res := item.data
 	item.mutex.Unlock()
+	atomic.AddInt64(&c.hits, 1)
+	atomic.AddInt64(&c.bytesOut, int64(len(res)))
 	return res, true
 }
 
@@ -77,9 +139,14 @@ func (c *Bytes) Store(id int, p []byte) {
 	}
 	item := c.vals[id]
 	if item == nil {
-		item = &entryBytes{data: p, lastAccess: time.Now().Unix()}
+		item = &entryBytes{data: p, lastAccess: time.Now().Unix(), id: id}
 		c.vals[id] = item
 		atomic.AddInt64(&c.mem, int64(len(p)))
+		atomic.AddInt64(&c.bytesIn, int64(len(p)))
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+			c.sieveEvict()
+		}
 	}
 }
 
@@ -91,9 +158,29 @@ func (c *Bytes) Replace(id int, p []byte) {
 	tim := time.Now().Unix()
 	item := c.vals[id]
 	if item == nil {
-		item = &entryBytes{data: p, lastAccess: tim}
+		item = &entryBytes{data: p, lastAccess: tim, id: id}
 		c.vals[id] = item
 		atomic.AddInt64(&c.mem, int64(len(p)))
+		atomic.AddInt64(&c.bytesIn, int64(len(p)))
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
+	} else if atomic.LoadInt32(&item.refcount) > 0 {
+		// outstanding Handles may still be reading item.data; detach it instead of mutating
+		// it in place, and give the id a fresh entry so later callers see the new value
+		if c.policy == PolicySIEVE {
+			c.listMutex.Lock()
+			c.sieveUnlink(item)
+			c.listMutex.Unlock()
+		}
+		atomic.AddInt64(&c.mem, 0-int64(len(item.data)))
+		item = &entryBytes{data: p, lastAccess: tim, id: id}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, int64(len(p)))
+		atomic.AddInt64(&c.bytesIn, int64(len(p)))
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
 	} else {
 		item.mutex.Lock()
 		memdif := len(p) - len(item.data)
@@ -101,42 +188,158 @@ func (c *Bytes) Replace(id int, p []byte) {
 		item.lastAccess = tim
 		item.mutex.Unlock()
 		atomic.AddInt64(&c.mem, int64(memdif))
+		atomic.AddInt64(&c.bytesIn, int64(len(p)))
+	}
+	if c.policy == PolicySIEVE {
+		c.sieveEvict()
 	}
 }
 
-// Delete an entry from the cache
+// Delete an entry from the cache; if an outstanding Handle is pinning it, the removal is
+// deferred until the handle's last Release, the same way Purge defers a refcounted entry
 func (c *Bytes) Remove(id int) {
 	if id >= c.size {
 		return
 	}
-	c.vals[id] = false
+	item := c.vals[id]
+	if item == nil {
+		return
+	}
+	if c.backend != nil {
+		c.backend.Remove(id)
+	}
+	if atomic.LoadInt32(&item.refcount) > 0 {
+		atomic.StoreInt32(&item.removing, 1)
+		atomic.StoreInt32(&item.marked, 1)
+		return
+	}
+	c.vals[id] = nil
+	if c.policy == PolicySIEVE {
+		c.listMutex.Lock()
+		c.sieveUnlink(item)
+		c.listMutex.Unlock()
+	}
+	item.mutex.Lock()
+	dataLen := len(item.data)
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.mem, 0-int64(dataLen))
 }
 
 // Closes the cache, releasing the memory
 func (c *Bytes) Close() {
+	if c.backend != nil {
+		c.backend.Close()
+	}
 	c.size = 0
 	c.vals = nil
 	c.mem = 0
 	c.max = 0
+	c.head, c.tail, c.hand = nil, nil, nil
 }
 
-// Removes all entries in the cache last accessed less than this time ago (UNIX Timestamp)
+// Removes all entries in the cache last accessed less than this time ago (UNIX Timestamp);
+// if a Backend is configured, purged entries spill to it instead of being lost
 func (c *Bytes) Purge(olderThan int64) {
 	for i, item := range c.vals {
 		if item != nil {
 			item.mutex.Lock()
-			if item.lastAccess < olderThan {
-				atomic.AddInt64(&c.mem, 0 - int64(len(item.data)))
-				c.vals[i] = nil
+			if item.lastAccess >= olderThan {
+				item.mutex.Unlock()
+				continue
 			}
+			if atomic.LoadInt32(&item.refcount) > 0 {
+				atomic.StoreInt32(&item.marked, 1)
+				item.mutex.Unlock()
+				continue
+			}
+			data := item.data
+			atomic.AddInt64(&c.mem, 0 - int64(len(data)))
+			atomic.AddInt64(&c.evictions, 1)
+			c.vals[i] = nil
 			item.mutex.Unlock()
+			if c.backend != nil {
+				c.backend.Put(i, data)
+			}
+		}
+	}
+}
+
+// sieveInsert adds a freshly stored entry to the head of the SIEVE list
+func (c *Bytes) sieveInsert(item *entryBytes) {
+	c.listMutex.Lock()
+	item.next = c.head
+	item.prev = nil
+	if c.head != nil {
+		c.head.prev = item
+	}
+	c.head = item
+	if c.tail == nil {
+		c.tail = item
+	}
+	c.listMutex.Unlock()
+}
+
+// sieveUnlink removes an entry from the SIEVE list; caller holds listMutex
+func (c *Bytes) sieveUnlink(item *entryBytes) {
+	if item.prev != nil {
+		item.prev.next = item.next
+	} else {
+		c.head = item.next
+	}
+	if item.next != nil {
+		item.next.prev = item.prev
+	} else {
+		c.tail = item.prev
+	}
+	if c.hand == item {
+		c.hand = item.prev
+	}
+}
+
+// sieveEvict walks the hand backward, clearing visited bits, until it finds and evicts an
+// unvisited entry; it repeats until the cache is back under budget or it has taken
+// c.size+1 steps, so a call can return with the cache still over budget. Entries held by an
+// outstanding Handle are skipped and marked for removal once their last handle is released.
+func (c *Bytes) sieveEvict() {
+	c.listMutex.Lock()
+	defer c.listMutex.Unlock()
+	for steps := 0; atomic.LoadInt64(&c.mem) > atomic.LoadInt64(&c.max) && steps <= c.size; steps++ {
+		node := c.hand
+		if node == nil {
+			node = c.tail
+			if node == nil {
+				return
+			}
+		}
+		if atomic.LoadInt32(&node.visited) == 1 {
+			atomic.StoreInt32(&node.visited, 0)
+			c.hand = node.prev
+			continue
+		}
+		if atomic.LoadInt32(&node.refcount) > 0 {
+			atomic.StoreInt32(&node.marked, 1)
+			c.hand = node.prev
+			continue
+		}
+		c.hand = node.prev
+		c.sieveUnlink(node)
+		c.vals[node.id] = nil
+		atomic.AddInt64(&c.mem, 0 - int64(len(node.data)))
+		atomic.AddInt64(&c.evictions, 1)
+		if c.backend != nil {
+			c.backend.Put(node.id, node.data)
 		}
 	}
 }
 
-// Creates a new cache
+// Creates a new cache using the tiered time-based purge (PolicyLRU)
 func New(size int, megabytes int64) *Interface {
-	c := &Interface{vals: make([]*entryInterface, size), size: size, max: megabytes * 1024}
+	return NewWithPolicy(size, megabytes, PolicyLRU)
+}
+
+// Creates a new cache using the given eviction policy
+func NewWithPolicy(size int, megabytes int64, policy Policy) *Interface {
+	c := &Interface{vals: make([]*entryInterface, size), size: size, max: megabytes * 1024, policy: policy}
 	cachesMutex2.Lock()
 	caches2 = append(caches2, c)
 	cachesMutex2.Unlock()
@@ -150,13 +353,26 @@ func (c *Interface) Get(id int) (interface{}, bool) {
 	}
 	item := c.vals[id]
 	if item == nil {
+		atomic.AddInt64(&c.misses, 1)
 		return nil, false
 	}
+	if item.expiresAt != 0 && time.Now().Unix() > item.expiresAt {
+		c.dropExpired(id, item)
+		atomic.AddInt64(&c.expirations, 1)
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	if c.policy == PolicySIEVE {
+		atomic.StoreInt32(&item.visited, 1)
+	}
 	tim := time.Now().Unix()
 	item.mutex.Lock()
 	item.lastAccess = tim
 	res := item.data
+	size := item.size
 	item.mutex.Unlock()
+	atomic.AddInt64(&c.hits, 1)
+	atomic.AddInt64(&c.bytesOut, size)
 	return res, true
 }
 
@@ -167,9 +383,14 @@ func (c *Interface) Store(id int, p interface{}, size int64) {
 	}
 	item := c.vals[id]
 	if item == nil {
-		item = &entryInterface{data: p, lastAccess: time.Now().Unix(), size: size}
+		item = &entryInterface{data: p, lastAccess: time.Now().Unix(), size: size, id: id}
 		c.vals[id] = item
 		atomic.AddInt64(&c.mem, size)
+		atomic.AddInt64(&c.bytesIn, size)
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+			c.sieveEvict()
+		}
 	}
 }
 
@@ -181,9 +402,29 @@ func (c *Interface) Replace(id int, p []byte, size int64) {
 	tim := time.Now().Unix()
 	item := c.vals[id]
 	if item == nil {
-		item = &entryInterface{data: p, lastAccess: tim, size: size}
+		item = &entryInterface{data: p, lastAccess: tim, size: size, id: id}
 		c.vals[id] = item
 		atomic.AddInt64(&c.mem, size)
+		atomic.AddInt64(&c.bytesIn, size)
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
+	} else if atomic.LoadInt32(&item.refcount) > 0 {
+		// outstanding Handles may still be reading item.data; detach it instead of mutating
+		// it in place, and give the id a fresh entry so later callers see the new value
+		if c.policy == PolicySIEVE {
+			c.listMutex.Lock()
+			c.sieveUnlink(item)
+			c.listMutex.Unlock()
+		}
+		atomic.AddInt64(&c.mem, 0-item.size)
+		item = &entryInterface{data: p, lastAccess: tim, size: size, id: id}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, size)
+		atomic.AddInt64(&c.bytesIn, size)
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
 	} else {
 		item.mutex.Lock()
 		size -= item.size
@@ -191,15 +432,38 @@ func (c *Interface) Replace(id int, p []byte, size int64) {
 		item.lastAccess = tim
 		item.mutex.Unlock()
 		atomic.AddInt64(&c.mem, size)
+		atomic.AddInt64(&c.bytesIn, size)
+	}
+	if c.policy == PolicySIEVE {
+		c.sieveEvict()
 	}
 }
 
-// Delete an entry from the cache
+// Delete an entry from the cache; if an outstanding Handle is pinning it, the removal is
+// deferred until the handle's last Release, the same way Purge defers a refcounted entry
 func (c *Interface) Remove(id int) {
 	if id >= c.size {
 		return
 	}
-	c.vals[id] = false
+	item := c.vals[id]
+	if item == nil {
+		return
+	}
+	if atomic.LoadInt32(&item.refcount) > 0 {
+		atomic.StoreInt32(&item.removing, 1)
+		atomic.StoreInt32(&item.marked, 1)
+		return
+	}
+	c.vals[id] = nil
+	if c.policy == PolicySIEVE {
+		c.listMutex.Lock()
+		c.sieveUnlink(item)
+		c.listMutex.Unlock()
+	}
+	item.mutex.Lock()
+	size := item.size
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.mem, 0-size)
 }
 
 // Closes the cache, releasing the memory
@@ -208,6 +472,7 @@ func (c *Interface) Close() {
 	c.vals = nil
 	c.mem = 0
 	c.max = 0
+	c.head, c.tail, c.hand = nil, nil, nil
 }
 
 // Removes all entries in the cache last accessed less than this time ago (UNIX Timestamp)
@@ -215,17 +480,89 @@ func (c *Interface) Purge(olderThan int64) {
 	for i, item := range c.vals {
 		if item != nil {
 			item.mutex.Lock()
-			if item.lastAccess < olderThan {
-				atomic.AddInt64(&c.mem, 0 - item.size)
-				c.vals[i] = nil
+			if item.lastAccess >= olderThan {
+				item.mutex.Unlock()
+				continue
+			}
+			if atomic.LoadInt32(&item.refcount) > 0 {
+				atomic.StoreInt32(&item.marked, 1)
+				item.mutex.Unlock()
+				continue
 			}
+			atomic.AddInt64(&c.mem, 0 - item.size)
+			atomic.AddInt64(&c.evictions, 1)
+			c.vals[i] = nil
 			item.mutex.Unlock()
 		}
 	}
 }
 
+// sieveInsert adds a freshly stored entry to the head of the SIEVE list
+func (c *Interface) sieveInsert(item *entryInterface) {
+	c.listMutex.Lock()
+	item.next = c.head
+	item.prev = nil
+	if c.head != nil {
+		c.head.prev = item
+	}
+	c.head = item
+	if c.tail == nil {
+		c.tail = item
+	}
+	c.listMutex.Unlock()
+}
+
+// sieveUnlink removes an entry from the SIEVE list; caller holds listMutex
+func (c *Interface) sieveUnlink(item *entryInterface) {
+	if item.prev != nil {
+		item.prev.next = item.next
+	} else {
+		c.head = item.next
+	}
+	if item.next != nil {
+		item.next.prev = item.prev
+	} else {
+		c.tail = item.prev
+	}
+	if c.hand == item {
+		c.hand = item.prev
+	}
+}
 
-// Automatically purges all caches
+// sieveEvict walks the hand backward, clearing visited bits, until it finds and evicts an
+// unvisited entry; it repeats until the cache is back under budget or it has taken
+// c.size+1 steps, so a call can return with the cache still over budget. Entries held by an
+// outstanding Handle are skipped and marked for removal once their last handle is released.
+func (c *Interface) sieveEvict() {
+	c.listMutex.Lock()
+	defer c.listMutex.Unlock()
+	for steps := 0; atomic.LoadInt64(&c.mem) > atomic.LoadInt64(&c.max) && steps <= c.size; steps++ {
+		node := c.hand
+		if node == nil {
+			node = c.tail
+			if node == nil {
+				return
+			}
+		}
+		if atomic.LoadInt32(&node.visited) == 1 {
+			atomic.StoreInt32(&node.visited, 0)
+			c.hand = node.prev
+			continue
+		}
+		if atomic.LoadInt32(&node.refcount) > 0 {
+			atomic.StoreInt32(&node.marked, 1)
+			c.hand = node.prev
+			continue
+		}
+		c.hand = node.prev
+		c.sieveUnlink(node)
+		c.vals[node.id] = nil
+		atomic.AddInt64(&c.mem, 0 - node.size)
+		atomic.AddInt64(&c.evictions, 1)
+	}
+}
+
+// Automatically purges all PolicyLRU caches; PolicySIEVE caches evict inline on Store/Replace
 func cleaner() {
 	for {
 		time.Sleep(sleeptime)
@@ -234,6 +571,10 @@ func cleaner() {
 		newCachesSlice1 := caches1
 		cachesMutex1.Unlock()
 		for _, c := range newCachesSlice1 {
+			c.purgeExpired()
+			if c.policy != PolicyLRU {
+				continue
+			}
 			if atomic.LoadInt64(&c.mem) > atomic.LoadInt64(&c.max) {
 				c.Purge(time.Now().Unix() - 432000) // 5 days ago
 			} else {
@@ -265,6 +606,10 @@ func cleaner() {
 		newCachesSlice2 := caches2
 		cachesMutex2.Unlock()
 		for _, c := range newCachesSlice2 {
+			c.purgeExpired()
+			if c.policy != PolicyLRU {
+				continue
+			}
 			if atomic.LoadInt64(&c.mem) > atomic.LoadInt64(&c.max) {
 				c.Purge(time.Now().Unix() - 432000) // 5 days ago
 			} else {
@@ -291,6 +636,6 @@ func cleaner() {
 				continue
 			}
 		}
-		
+
 	}
 }
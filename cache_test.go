@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Purge used to check refcount before checking whether the entry even qualified by age,
+// so any entry with an outstanding handle got marked for removal regardless of how
+// recently it was accessed.
+func TestBytesPurgeOnlyMarksQualifyingEntries(t *testing.T) {
+	c := NewBytes(4, 1)
+	c.Store(0, []byte("hot"))
+	h, ok := c.GetHandle(0)
+	if !ok {
+		t.Fatal("GetHandle(0) = false, want true")
+	}
+
+	c.Purge(time.Now().Unix() - 3600) // nothing is older than an hour ago
+
+	h.Release()
+
+	got, ok := c.Get(0)
+	if !ok || string(got) != "hot" {
+		t.Fatalf("Get(0) after Purge+Release = %q, %v; want %q, true (handle release wrongly finalized a non-qualifying entry)", got, ok, "hot")
+	}
+}
+
+// Remove used to assign an untyped false into c.vals (a compile error) and, once fixed,
+// still left the old node dangling in the SIEVE list instead of unlinking it. A later
+// Store at the same id then installed a second node for that id, and sieveEvict walking
+// onto the orphaned ghost node would wipe out the new, live entry.
+func TestBytesRemoveUnlinksSieveNode(t *testing.T) {
+	c := NewBytesWithPolicy(4, 1, PolicySIEVE)
+	c.max = 12 // storing "force-evict" (11 bytes) next to "b" and "c" goes over budget
+	c.Store(0, []byte("a"))
+	c.Store(1, []byte("b"))
+	c.Remove(0)
+	c.Store(0, []byte("c"))
+
+	for i := 0; i < 100; i++ {
+		c.Store(2, []byte("force-evict"))
+		c.Remove(2)
+	}
+
+	got, ok := c.Get(0)
+	if !ok || string(got) != "c" {
+		t.Fatalf("Get(0) = %q, %v; want %q, true (ghost SIEVE node clobbered the new entry)", got, ok, "c")
+	}
+}
+
+// Remove never checked refcount at all, unconditionally nil-ing the slot and freeing
+// accounting even with an outstanding Handle, unlike Purge/sieveEvict/dropExpired which
+// all defer to finalizeRemoval when refcount > 0.
+func TestBytesRemoveDefersWhileHandleOutstanding(t *testing.T) {
+	c := NewBytes(4, 1)
+	c.Store(0, []byte("pinned"))
+	h, ok := c.GetHandle(0)
+	if !ok {
+		t.Fatal("GetHandle(0) = false, want true")
+	}
+
+	c.Remove(0)
+
+	if string(h.Bytes()) != "pinned" {
+		t.Fatalf("h.Bytes() = %q, want %q", h.Bytes(), "pinned")
+	}
+	h.Release()
+
+	if c.Mem() != 0 {
+		t.Fatalf("Mem() after releasing a removed, refcounted entry = %d, want 0", c.Mem())
+	}
+	if _, ok := c.Get(0); ok {
+		t.Fatal("Get(0) after deferred Remove finalized = true, want false")
+	}
+}
+
+// Remove/finalizeRemoval/dropExpired read item.data/item.size without holding item.mutex,
+// while Replace's in-place branch mutates those same fields under item.mutex. Run with
+// -race to catch the slice-header race.
+func TestBytesRemoveRacesWithReplace(t *testing.T) {
+	c := NewBytes(4, 1)
+	c.Store(0, []byte("initial"))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.Replace(0, []byte("replaced"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.Remove(0)
+			c.Store(0, []byte("restored"))
+		}
+	}()
+	wg.Wait()
+}
+
+func TestBytesRemoveDecrementsMem(t *testing.T) {
+	c := NewBytes(4, 1)
+	c.Store(0, []byte("hello"))
+	if c.Mem() != 5 {
+		t.Fatalf("Mem() = %d, want 5", c.Mem())
+	}
+	c.Remove(0)
+	if c.Mem() != 0 {
+		t.Fatalf("Mem() after Remove = %d, want 0", c.Mem())
+	}
+	if _, ok := c.Get(0); ok {
+		t.Fatal("Get(0) after Remove = true, want false")
+	}
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// BytesHandle pins an entry in a Bytes cache so it can't be evicted out from under a reader;
+// Release must be called exactly once when the caller is done with the returned slice.
+type BytesHandle struct {
+	c *Bytes
+	item *entryBytes
+	data []byte
+}
+
+// Bytes returns the slice of bytes this handle pins
+func (h *BytesHandle) Bytes() []byte {
+	return h.data
+}
+
+// Release gives up this handle; once the last outstanding handle on an entry marked for
+// removal is released, the entry is actually freed
+func (h *BytesHandle) Release() {
+	if atomic.AddInt32(&h.item.refcount, -1) == 0 && atomic.LoadInt32(&h.item.marked) == 1 {
+		h.c.finalizeRemoval(h.item)
+	}
+}
+
+// Gets a handle to the slice of bytes assigned to this ID; the entry is pinned against
+// Purge and inline eviction until the handle's Release is called
+func (c *Bytes) GetHandle(id int) (*BytesHandle, bool) {
+	if id >= c.size {
+		return nil, false
+	}
+	item := c.vals[id]
+	if item == nil {
+		if c.backend != nil {
+			if p, ok := c.backend.Get(id); ok {
+				c.Store(id, p)
+				return c.GetHandle(id)
+			}
+		}
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	if item.expiresAt != 0 && time.Now().Unix() > item.expiresAt {
+		c.dropExpired(id, item)
+		atomic.AddInt64(&c.expirations, 1)
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddInt32(&item.refcount, 1)
+	if c.policy == PolicySIEVE {
+		atomic.StoreInt32(&item.visited, 1)
+	}
+	tim := time.Now().Unix()
+	item.mutex.Lock()
+	item.lastAccess = tim
+	res := item.data
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.hits, 1)
+	atomic.AddInt64(&c.bytesOut, int64(len(res)))
+	return &BytesHandle{c: c, item: item, data: res}, true
+}
+
+// finalizeRemoval actually frees an entry once its last handle is released, provided it's
+// still the live entry for its id (Replace may already have detached and swapped it out)
+func (c *Bytes) finalizeRemoval(item *entryBytes) {
+	if c.vals[item.id] != item {
+		return
+	}
+	if c.policy == PolicySIEVE {
+		c.listMutex.Lock()
+		c.sieveUnlink(item)
+		c.listMutex.Unlock()
+	}
+	c.vals[item.id] = nil
+	item.mutex.Lock()
+	data := item.data
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.mem, 0-int64(len(data)))
+	if atomic.LoadInt32(&item.removing) == 1 {
+		// an explicit Remove was deferred, not an eviction; the backend was already told
+		// to drop this id when Remove was called, so don't spill the stale data back to it
+		return
+	}
+	atomic.AddInt64(&c.evictions, 1)
+	if c.backend != nil {
+		c.backend.Put(item.id, data)
+	}
+}
+
+// InterfaceHandle pins an entry in an Interface cache so it can't be evicted out from under
+// a reader; Release must be called exactly once when the caller is done with the value.
+type InterfaceHandle struct {
+	c *Interface
+	item *entryInterface
+	data interface{}
+}
+
+// Value returns the value this handle pins
+func (h *InterfaceHandle) Value() interface{} {
+	return h.data
+}
+
+// Release gives up this handle; once the last outstanding handle on an entry marked for
+// removal is released, the entry is actually freed
+func (h *InterfaceHandle) Release() {
+	if atomic.AddInt32(&h.item.refcount, -1) == 0 && atomic.LoadInt32(&h.item.marked) == 1 {
+		h.c.finalizeRemoval(h.item)
+	}
+}
+
+// Gets a handle to the value assigned to this ID; the entry is pinned against Purge and
+// inline eviction until the handle's Release is called
+func (c *Interface) GetHandle(id int) (*InterfaceHandle, bool) {
+	if id >= c.size {
+		return nil, false
+	}
+	item := c.vals[id]
+	if item == nil {
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	if item.expiresAt != 0 && time.Now().Unix() > item.expiresAt {
+		c.dropExpired(id, item)
+		atomic.AddInt64(&c.expirations, 1)
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddInt32(&item.refcount, 1)
+	if c.policy == PolicySIEVE {
+		atomic.StoreInt32(&item.visited, 1)
+	}
+	tim := time.Now().Unix()
+	item.mutex.Lock()
+	item.lastAccess = tim
+	res := item.data
+	size := item.size
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.hits, 1)
+	atomic.AddInt64(&c.bytesOut, size)
+	return &InterfaceHandle{c: c, item: item, data: res}, true
+}
+
+// finalizeRemoval actually frees an entry once its last handle is released, provided it's
+// still the live entry for its id (Replace may already have detached and swapped it out)
+func (c *Interface) finalizeRemoval(item *entryInterface) {
+	if c.vals[item.id] != item {
+		return
+	}
+	if c.policy == PolicySIEVE {
+		c.listMutex.Lock()
+		c.sieveUnlink(item)
+		c.listMutex.Unlock()
+	}
+	c.vals[item.id] = nil
+	item.mutex.Lock()
+	size := item.size
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.mem, 0-size)
+	if atomic.LoadInt32(&item.removing) == 1 {
+		return
+	}
+	atomic.AddInt64(&c.evictions, 1)
+}
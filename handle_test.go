@@ -0,0 +1,65 @@
+package cache
+
+import "testing"
+
+type stubBackend struct {
+	data map[int][]byte
+}
+
+func newStubBackend() *stubBackend {
+	return &stubBackend{data: make(map[int][]byte)}
+}
+
+func (b *stubBackend) Get(id int) ([]byte, bool) {
+	p, ok := b.data[id]
+	return p, ok
+}
+
+func (b *stubBackend) Put(id int, p []byte) {
+	b.data[id] = p
+}
+
+func (b *stubBackend) Remove(id int) {
+	delete(b.data, id)
+}
+
+func (b *stubBackend) Close() {}
+
+// GetHandle didn't mirror Get's backend-promotion branch, so an id that only lived on the
+// backend's disk tier returned a miss instead of transparently promoting into memory.
+func TestGetHandlePromotesFromBackend(t *testing.T) {
+	backend := newStubBackend()
+	backend.Put(0, []byte("from-disk"))
+	c := NewBytes(4, 1, backend)
+
+	h, ok := c.GetHandle(0)
+	if !ok {
+		t.Fatal("GetHandle(0) = false, want true (should promote from backend)")
+	}
+	if string(h.Bytes()) != "from-disk" {
+		t.Fatalf("h.Bytes() = %q, want %q", h.Bytes(), "from-disk")
+	}
+	h.Release()
+}
+
+// finalizeRemoval never spilled to the backend, unlike Purge and sieveEvict, so data
+// marked for removal while a handle was outstanding was silently dropped instead of
+// surviving on disk.
+func TestFinalizeRemovalSpillsToBackend(t *testing.T) {
+	backend := newStubBackend()
+	c := NewBytesWithPolicy(4, 1, PolicySIEVE, backend)
+	c.Store(0, []byte("pinned"))
+	h, ok := c.GetHandle(0)
+	if !ok {
+		t.Fatal("GetHandle(0) = false, want true")
+	}
+
+	c.max = 1 // shrink the budget so the next Store leaves item 0 permanently over budget
+	c.Store(1, []byte("x"))
+
+	h.Release()
+
+	if p, ok := backend.Get(0); !ok || string(p) != "pinned" {
+		t.Fatalf("backend.Get(0) = %q, %v; want %q, true", p, ok, "pinned")
+	}
+}
@@ -0,0 +1,472 @@
+package cache
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default number of shards used by NewMap/NewMapBytes
+const defaultShards = 1 << 10
+
+func shardFor(key string, numShards uint32) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32() % numShards
+}
+
+type mapEntryBytes struct {
+	mutex sync.Mutex
+	lastAccess int64
+	data []byte
+	key string
+	visited int32
+	prev, next *mapEntryBytes
+}
+
+type mapShardBytes struct {
+	mutex sync.Mutex
+	vals map[string]*mapEntryBytes
+	mem int64
+	head, tail, hand *mapEntryBytes
+}
+
+// MapBytes is a sharded, string-keyed []byte cache that grows dynamically instead of
+// requiring a pre-sized, int-indexed slice. Each shard evicts against its own slice of
+// the budget (max/numShards) so a write-heavy shard can't be starved by evicting itself
+// to try to close a gap left by a shard it doesn't control.
+type MapBytes struct {
+	shards []*mapShardBytes
+	numShards uint32
+	mem, max, shardMax int64
+	policy Policy
+}
+
+// Creates a new string-keyed cache with the default shard count and PolicyLRU. A MapBytes is
+// not purged by the background timer, so under PolicyLRU entries leave it only through
+// Remove or an explicit Purge.
+func NewMapBytes(megabytes int64) *MapBytes {
+	return NewMapBytesWithPolicy(megabytes, PolicyLRU, defaultShards)
+}
+
+// Creates a new string-keyed cache with the given eviction policy and shard count
+func NewMapBytesWithPolicy(megabytes int64, policy Policy, numShards int) *MapBytes {
+	if numShards <= 0 {
+		numShards = defaultShards
+	}
+	max := megabytes * 1048576
+	c := &MapBytes{shards: make([]*mapShardBytes, numShards), numShards: uint32(numShards), max: max, shardMax: max / int64(numShards), policy: policy}
+	for i := range c.shards {
+		c.shards[i] = &mapShardBytes{vals: make(map[string]*mapEntryBytes)}
+	}
+	return c
+}
+
+func (c *MapBytes) shard(key string) *mapShardBytes {
+	return c.shards[shardFor(key, c.numShards)]
+}
+
+// Gets the slice of bytes assigned to this key in the cache
+func (c *MapBytes) Get(key string) ([]byte, bool) {
+	s := c.shard(key)
+	s.mutex.Lock()
+	item, ok := s.vals[key]
+	s.mutex.Unlock()
+	if !ok {
+		return nil, false
+	}
+	if c.policy == PolicySIEVE {
+		atomic.StoreInt32(&item.visited, 1)
+	}
+	tim := time.Now().Unix()
+	item.mutex.Lock()
+	item.lastAccess = tim
+	res := item.data
+	item.mutex.Unlock()
+	return res, true
+}
+
+// Caches the item, does nothing if it already exists
+func (c *MapBytes) Store(key string, p []byte) {
+	s := c.shard(key)
+	s.mutex.Lock()
+	if _, ok := s.vals[key]; ok {
+		s.mutex.Unlock()
+		return
+	}
+	item := &mapEntryBytes{data: p, lastAccess: time.Now().Unix(), key: key}
+	s.vals[key] = item
+	if c.policy == PolicySIEVE {
+		s.sieveInsert(item)
+	}
+	atomic.AddInt64(&s.mem, int64(len(p)))
+	s.mutex.Unlock()
+	atomic.AddInt64(&c.mem, int64(len(p)))
+	if c.policy == PolicySIEVE {
+		c.sieveEvict(s)
+	}
+}
+
+// Caches the item, replaces it if it already exists
+func (c *MapBytes) Replace(key string, p []byte) {
+	s := c.shard(key)
+	tim := time.Now().Unix()
+	s.mutex.Lock()
+	item, ok := s.vals[key]
+	if !ok {
+		item = &mapEntryBytes{data: p, lastAccess: tim, key: key}
+		s.vals[key] = item
+		if c.policy == PolicySIEVE {
+			s.sieveInsert(item)
+		}
+		atomic.AddInt64(&s.mem, int64(len(p)))
+		s.mutex.Unlock()
+		atomic.AddInt64(&c.mem, int64(len(p)))
+	} else {
+		s.mutex.Unlock()
+		item.mutex.Lock()
+		memdif := len(p) - len(item.data)
+		item.data = p
+		item.lastAccess = tim
+		item.mutex.Unlock()
+		atomic.AddInt64(&s.mem, int64(memdif))
+		atomic.AddInt64(&c.mem, int64(memdif))
+	}
+	if c.policy == PolicySIEVE {
+		c.sieveEvict(s)
+	}
+}
+
+// Delete an entry from the cache
+func (c *MapBytes) Remove(key string) {
+	s := c.shard(key)
+	s.mutex.Lock()
+	item, ok := s.vals[key]
+	if ok {
+		delete(s.vals, key)
+		if c.policy == PolicySIEVE {
+			s.sieveUnlink(item)
+		}
+	}
+	s.mutex.Unlock()
+	if ok {
+		item.mutex.Lock()
+		dataLen := len(item.data)
+		item.mutex.Unlock()
+		atomic.AddInt64(&s.mem, 0-int64(dataLen))
+		atomic.AddInt64(&c.mem, 0-int64(dataLen))
+	}
+}
+
+// Removes all entries in the cache last accessed less than this time ago (UNIX Timestamp)
+func (c *MapBytes) Purge(olderThan int64) {
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		for key, item := range s.vals {
+			item.mutex.Lock()
+			if item.lastAccess < olderThan {
+				atomic.AddInt64(&s.mem, 0-int64(len(item.data)))
+				atomic.AddInt64(&c.mem, 0-int64(len(item.data)))
+				delete(s.vals, key)
+				if c.policy == PolicySIEVE {
+					s.sieveUnlink(item)
+				}
+			}
+			item.mutex.Unlock()
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// Closes the cache, releasing the memory
+func (c *MapBytes) Close() {
+	c.shards = nil
+	c.mem = 0
+	c.max = 0
+}
+
+// sieveInsert adds a freshly stored entry to the head of the shard's SIEVE list; caller holds s.mutex
+func (s *mapShardBytes) sieveInsert(item *mapEntryBytes) {
+	item.next = s.head
+	item.prev = nil
+	if s.head != nil {
+		s.head.prev = item
+	}
+	s.head = item
+	if s.tail == nil {
+		s.tail = item
+	}
+}
+
+// sieveUnlink removes an entry from the shard's SIEVE list; caller holds s.mutex
+func (s *mapShardBytes) sieveUnlink(item *mapEntryBytes) {
+	if item.prev != nil {
+		item.prev.next = item.next
+	} else {
+		s.head = item.next
+	}
+	if item.next != nil {
+		item.next.prev = item.prev
+	} else {
+		s.tail = item.prev
+	}
+	if s.hand == item {
+		s.hand = item.prev
+	}
+}
+
+// sieveEvict walks the shard's own hand backward until that shard is back under its own
+// slice of the budget (c.shardMax), so a shard that never receives writes isn't relied on
+// to close a gap it has no entries to give up, and a hot shard isn't evicted past its share
+func (c *MapBytes) sieveEvict(s *mapShardBytes) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for atomic.LoadInt64(&s.mem) > atomic.LoadInt64(&c.shardMax) {
+		node := s.hand
+		if node == nil {
+			node = s.tail
+			if node == nil {
+				return
+			}
+		}
+		if atomic.LoadInt32(&node.visited) == 1 {
+			atomic.StoreInt32(&node.visited, 0)
+			s.hand = node.prev
+			continue
+		}
+		s.hand = node.prev
+		s.sieveUnlink(node)
+		delete(s.vals, node.key)
+		atomic.AddInt64(&s.mem, 0-int64(len(node.data)))
+		atomic.AddInt64(&c.mem, 0-int64(len(node.data)))
+	}
+}
+
+type mapEntryInterface struct {
+	mutex sync.Mutex
+	lastAccess int64
+	data interface{}
+	size int64
+	key string
+	visited int32
+	prev, next *mapEntryInterface
+}
+
+type mapShardInterface struct {
+	mutex sync.Mutex
+	vals map[string]*mapEntryInterface
+	mem int64
+	head, tail, hand *mapEntryInterface
+}
+
+// Map is a sharded, string-keyed interface{} cache that grows dynamically instead of
+// requiring a pre-sized, int-indexed slice. Each shard evicts against its own slice of
+// the budget (max/numShards) so a write-heavy shard can't be starved by evicting itself
+// to try to close a gap left by a shard it doesn't control.
+type Map struct {
+	shards []*mapShardInterface
+	numShards uint32
+	mem, max, shardMax int64
+	policy Policy
+}
+
+// Creates a new string-keyed cache with the default shard count and PolicyLRU. A Map is not
+// purged by the background timer, so under PolicyLRU entries leave it only through Remove
+// or an explicit Purge.
+func NewMap(megabytes int64) *Map {
+	return NewMapWithPolicy(megabytes, PolicyLRU, defaultShards)
+}
+
+// Creates a new string-keyed cache with the given eviction policy and shard count
+func NewMapWithPolicy(megabytes int64, policy Policy, numShards int) *Map {
+	if numShards <= 0 {
+		numShards = defaultShards
+	}
+	max := megabytes * 1024
+	c := &Map{shards: make([]*mapShardInterface, numShards), numShards: uint32(numShards), max: max, shardMax: max / int64(numShards), policy: policy}
+	for i := range c.shards {
+		c.shards[i] = &mapShardInterface{vals: make(map[string]*mapEntryInterface)}
+	}
+	return c
+}
+
+func (c *Map) shard(key string) *mapShardInterface {
+	return c.shards[shardFor(key, c.numShards)]
+}
+
+// Gets the value assigned to this key in the cache
+func (c *Map) Get(key string) (interface{}, bool) {
+	s := c.shard(key)
+	s.mutex.Lock()
+	item, ok := s.vals[key]
+	s.mutex.Unlock()
+	if !ok {
+		return nil, false
+	}
+	if c.policy == PolicySIEVE {
+		atomic.StoreInt32(&item.visited, 1)
+	}
+	tim := time.Now().Unix()
+	item.mutex.Lock()
+	item.lastAccess = tim
+	res := item.data
+	item.mutex.Unlock()
+	return res, true
+}
+
+// Caches the item, does nothing if it already exists
+func (c *Map) Store(key string, p interface{}, size int64) {
+	s := c.shard(key)
+	s.mutex.Lock()
+	if _, ok := s.vals[key]; ok {
+		s.mutex.Unlock()
+		return
+	}
+	item := &mapEntryInterface{data: p, lastAccess: time.Now().Unix(), size: size, key: key}
+	s.vals[key] = item
+	if c.policy == PolicySIEVE {
+		s.sieveInsert(item)
+	}
+	atomic.AddInt64(&s.mem, size)
+	s.mutex.Unlock()
+	atomic.AddInt64(&c.mem, size)
+	if c.policy == PolicySIEVE {
+		c.sieveEvict(s)
+	}
+}
+
+// Caches the item, replaces it if it already exists
+func (c *Map) Replace(key string, p interface{}, size int64) {
+	s := c.shard(key)
+	tim := time.Now().Unix()
+	s.mutex.Lock()
+	item, ok := s.vals[key]
+	if !ok {
+		item = &mapEntryInterface{data: p, lastAccess: tim, size: size, key: key}
+		s.vals[key] = item
+		if c.policy == PolicySIEVE {
+			s.sieveInsert(item)
+		}
+		atomic.AddInt64(&s.mem, size)
+		s.mutex.Unlock()
+		atomic.AddInt64(&c.mem, size)
+	} else {
+		s.mutex.Unlock()
+		item.mutex.Lock()
+		size -= item.size
+		item.data = p
+		item.lastAccess = tim
+		item.mutex.Unlock()
+		atomic.AddInt64(&s.mem, size)
+		atomic.AddInt64(&c.mem, size)
+	}
+	if c.policy == PolicySIEVE {
+		c.sieveEvict(s)
+	}
+}
+
+// Delete an entry from the cache
+func (c *Map) Remove(key string) {
+	s := c.shard(key)
+	s.mutex.Lock()
+	item, ok := s.vals[key]
+	if ok {
+		delete(s.vals, key)
+		if c.policy == PolicySIEVE {
+			s.sieveUnlink(item)
+		}
+	}
+	s.mutex.Unlock()
+	if ok {
+		item.mutex.Lock()
+		size := item.size
+		item.mutex.Unlock()
+		atomic.AddInt64(&s.mem, 0-size)
+		atomic.AddInt64(&c.mem, 0-size)
+	}
+}
+
+// Removes all entries in the cache last accessed less than this time ago (UNIX Timestamp)
+func (c *Map) Purge(olderThan int64) {
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		for key, item := range s.vals {
+			item.mutex.Lock()
+			if item.lastAccess < olderThan {
+				atomic.AddInt64(&s.mem, 0-item.size)
+				atomic.AddInt64(&c.mem, 0-item.size)
+				delete(s.vals, key)
+				if c.policy == PolicySIEVE {
+					s.sieveUnlink(item)
+				}
+			}
+			item.mutex.Unlock()
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// Closes the cache, releasing the memory
+func (c *Map) Close() {
+	c.shards = nil
+	c.mem = 0
+	c.max = 0
+}
+
+// sieveInsert adds a freshly stored entry to the head of the shard's SIEVE list; caller holds s.mutex
+func (s *mapShardInterface) sieveInsert(item *mapEntryInterface) {
+	item.next = s.head
+	item.prev = nil
+	if s.head != nil {
+		s.head.prev = item
+	}
+	s.head = item
+	if s.tail == nil {
+		s.tail = item
+	}
+}
+
+// sieveUnlink removes an entry from the shard's SIEVE list; caller holds s.mutex
+func (s *mapShardInterface) sieveUnlink(item *mapEntryInterface) {
+	if item.prev != nil {
+		item.prev.next = item.next
+	} else {
+		s.head = item.next
+	}
+	if item.next != nil {
+		item.next.prev = item.prev
+	} else {
+		s.tail = item.prev
+	}
+	if s.hand == item {
+		s.hand = item.prev
+	}
+}
+
+// sieveEvict walks the shard's own hand backward until that shard is back under its own
+// slice of the budget (c.shardMax), so a shard that never receives writes isn't relied on
+// to close a gap it has no entries to give up, and a hot shard isn't evicted past its share
+func (c *Map) sieveEvict(s *mapShardInterface) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for atomic.LoadInt64(&s.mem) > atomic.LoadInt64(&c.shardMax) {
+		node := s.hand
+		if node == nil {
+			node = s.tail
+			if node == nil {
+				return
+			}
+		}
+		if atomic.LoadInt32(&node.visited) == 1 {
+			atomic.StoreInt32(&node.visited, 0)
+			s.hand = node.prev
+			continue
+		}
+		s.hand = node.prev
+		s.sieveUnlink(node)
+		delete(s.vals, node.key)
+		atomic.AddInt64(&s.mem, 0-node.size)
+		atomic.AddInt64(&c.mem, 0-node.size)
+	}
+}
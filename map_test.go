@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// sieveEvict used to compare a shard's own usage against the cache-wide budget, so a
+// single hot shard could hoard memory without bound while idle shards never evicted
+// anything. Each shard now evicts against its own slice of the budget (max/numShards).
+func TestMapBytesShardEvictsAgainstItsOwnBudget(t *testing.T) {
+	const numShards = 4
+	c := NewMapBytesWithPolicy(1, PolicySIEVE, numShards)
+
+	target := c.shards[0]
+	for i := 0; ; i++ {
+		key := "k" + strconv.Itoa(i)
+		if c.shard(key) != target {
+			continue
+		}
+		c.Store(key, make([]byte, 8192))
+		if target.mem > c.shardMax {
+			t.Fatalf("shard mem = %d exceeds its own budget %d after storing %q", target.mem, c.shardMax, key)
+		}
+		if i > 2000 {
+			break
+		}
+	}
+}
+
+// Remove read item.data outside item.mutex while Replace's in-place branch writes it
+// under item.mutex. Run with -race to catch the slice-header race.
+func TestMapBytesRemoveRacesWithReplace(t *testing.T) {
+	c := NewMapBytes(1)
+	c.Store("k", []byte("initial"))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.Replace("k", []byte("replaced"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.Remove("k")
+			c.Store("k", []byte("restored"))
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,346 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// poolOwner lets the Pool's shared eviction list evict an entry without knowing whether it
+// came from a PoolBytes or a PoolInterface namespace
+type poolOwner interface {
+	evictSize() int64
+	evictRemove()
+}
+
+// poolNode is the shared SIEVE list node; every namespaced entry, regardless of type, lives
+// on the same list so a hot namespace can reclaim bytes from a cold one
+type poolNode struct {
+	prev, next *poolNode
+	visited int32
+	owner poolOwner
+}
+
+// Pool owns a single memory budget and vends namespaced sub-caches that all draw from and
+// evict against it, instead of each cache having its own independent limit
+type Pool struct {
+	mem, max int64
+	listMutex sync.Mutex
+	head, tail, hand *poolNode
+	nsMutex sync.Mutex
+	namespaces map[string]*Namespace
+}
+
+// Creates a new pool with a shared memory budget
+func NewPool(megabytes int64) *Pool {
+	return &Pool{max: megabytes * 1048576, namespaces: make(map[string]*Namespace)}
+}
+
+// Returns the named sub-cache namespace, creating it if it doesn't already exist
+func (p *Pool) Namespace(name string) *Namespace {
+	p.nsMutex.Lock()
+	defer p.nsMutex.Unlock()
+	ns, ok := p.namespaces[name]
+	if !ok {
+		ns = &Namespace{pool: p, name: name, bytesVals: make(map[int]*poolEntryBytes), interfaceVals: make(map[int]*poolEntryInterface)}
+		p.namespaces[name] = ns
+	}
+	return ns
+}
+
+// insert adds a freshly stored node to the head of the pool's shared SIEVE list
+func (p *Pool) insert(n *poolNode) {
+	p.listMutex.Lock()
+	n.next = p.head
+	n.prev = nil
+	if p.head != nil {
+		p.head.prev = n
+	}
+	p.head = n
+	if p.tail == nil {
+		p.tail = n
+	}
+	p.listMutex.Unlock()
+}
+
+// unlink removes a node from the pool's shared SIEVE list; caller holds listMutex
+func (p *Pool) unlink(n *poolNode) {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else {
+		p.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else {
+		p.tail = n.prev
+	}
+	if p.hand == n {
+		p.hand = n.prev
+	}
+}
+
+// evict walks the shared hand backward until every namespace together is back under the
+// pool's budget, reclaiming bytes from whichever namespace holds the coldest entries
+func (p *Pool) evict() {
+	p.listMutex.Lock()
+	defer p.listMutex.Unlock()
+	for atomic.LoadInt64(&p.mem) > atomic.LoadInt64(&p.max) {
+		node := p.hand
+		if node == nil {
+			node = p.tail
+			if node == nil {
+				return
+			}
+		}
+		if atomic.LoadInt32(&node.visited) == 1 {
+			atomic.StoreInt32(&node.visited, 0)
+			p.hand = node.prev
+			continue
+		}
+		p.hand = node.prev
+		p.unlink(node)
+		atomic.AddInt64(&p.mem, 0-node.owner.evictSize())
+		node.owner.evictRemove()
+	}
+}
+
+// Namespace is a named sub-cache of a Pool; its Bytes() and Interface() views account
+// against and evict from the pool's single shared budget
+type Namespace struct {
+	pool *Pool
+	name string
+	bytesMutex sync.Mutex
+	bytesVals map[int]*poolEntryBytes
+	interfaceMutex sync.Mutex
+	interfaceVals map[int]*poolEntryInterface
+}
+
+// Returns the []byte view of this namespace
+func (ns *Namespace) Bytes() *PoolBytes {
+	return &PoolBytes{ns: ns}
+}
+
+// Returns the interface{} view of this namespace
+func (ns *Namespace) Interface() *PoolInterface {
+	return &PoolInterface{ns: ns}
+}
+
+type poolEntryBytes struct {
+	mutex sync.Mutex
+	lastAccess int64
+	data []byte
+	id int
+	node poolNode
+	ns *Namespace
+}
+
+func (e *poolEntryBytes) evictSize() int64 {
+	e.mutex.Lock()
+	size := int64(len(e.data))
+	e.mutex.Unlock()
+	return size
+}
+
+func (e *poolEntryBytes) evictRemove() {
+	e.ns.bytesMutex.Lock()
+	delete(e.ns.bytesVals, e.id)
+	e.ns.bytesMutex.Unlock()
+}
+
+// PoolBytes is a []byte cache namespaced within a Pool
+type PoolBytes struct {
+	ns *Namespace
+}
+
+// Gets the slice of bytes assigned to this ID in the namespace
+func (c *PoolBytes) Get(id int) ([]byte, bool) {
+	ns := c.ns
+	ns.bytesMutex.Lock()
+	item, ok := ns.bytesVals[id]
+	ns.bytesMutex.Unlock()
+	if !ok {
+		return nil, false
+	}
+	atomic.StoreInt32(&item.node.visited, 1)
+	tim := time.Now().Unix()
+	item.mutex.Lock()
+	item.lastAccess = tim
+	res := item.data
+	item.mutex.Unlock()
+	return res, true
+}
+
+// Caches the item, does nothing if it already exists
+func (c *PoolBytes) Store(id int, p []byte) {
+	ns := c.ns
+	ns.bytesMutex.Lock()
+	if _, ok := ns.bytesVals[id]; ok {
+		ns.bytesMutex.Unlock()
+		return
+	}
+	item := &poolEntryBytes{data: p, lastAccess: time.Now().Unix(), id: id, ns: ns}
+	item.node.owner = item
+	ns.bytesVals[id] = item
+	ns.bytesMutex.Unlock()
+	ns.pool.insert(&item.node)
+	atomic.AddInt64(&ns.pool.mem, int64(len(p)))
+	ns.pool.evict()
+}
+
+// Caches the item, replaces it if it already exists
+func (c *PoolBytes) Replace(id int, p []byte) {
+	ns := c.ns
+	tim := time.Now().Unix()
+	ns.bytesMutex.Lock()
+	item, ok := ns.bytesVals[id]
+	if !ok {
+		item = &poolEntryBytes{data: p, lastAccess: tim, id: id, ns: ns}
+		item.node.owner = item
+		ns.bytesVals[id] = item
+		ns.bytesMutex.Unlock()
+		ns.pool.insert(&item.node)
+		atomic.AddInt64(&ns.pool.mem, int64(len(p)))
+	} else {
+		ns.bytesMutex.Unlock()
+		item.mutex.Lock()
+		memdif := len(p) - len(item.data)
+		item.data = p
+		item.lastAccess = tim
+		item.mutex.Unlock()
+		atomic.AddInt64(&ns.pool.mem, int64(memdif))
+	}
+	ns.pool.evict()
+}
+
+// Delete an entry from the namespace
+func (c *PoolBytes) Remove(id int) {
+	ns := c.ns
+	ns.pool.listMutex.Lock()
+	ns.bytesMutex.Lock()
+	item, ok := ns.bytesVals[id]
+	if ok {
+		delete(ns.bytesVals, id)
+	}
+	ns.bytesMutex.Unlock()
+	if ok {
+		ns.pool.unlink(&item.node)
+	}
+	ns.pool.listMutex.Unlock()
+	if ok {
+		item.mutex.Lock()
+		dataLen := len(item.data)
+		item.mutex.Unlock()
+		atomic.AddInt64(&ns.pool.mem, 0-int64(dataLen))
+	}
+}
+
+type poolEntryInterface struct {
+	mutex sync.Mutex
+	lastAccess int64
+	data interface{}
+	size int64
+	id int
+	node poolNode
+	ns *Namespace
+}
+
+func (e *poolEntryInterface) evictSize() int64 {
+	e.mutex.Lock()
+	size := e.size
+	e.mutex.Unlock()
+	return size
+}
+
+func (e *poolEntryInterface) evictRemove() {
+	e.ns.interfaceMutex.Lock()
+	delete(e.ns.interfaceVals, e.id)
+	e.ns.interfaceMutex.Unlock()
+}
+
+// PoolInterface is an interface{} cache namespaced within a Pool
+type PoolInterface struct {
+	ns *Namespace
+}
+
+// Gets the value assigned to this ID in the namespace
+func (c *PoolInterface) Get(id int) (interface{}, bool) {
+	ns := c.ns
+	ns.interfaceMutex.Lock()
+	item, ok := ns.interfaceVals[id]
+	ns.interfaceMutex.Unlock()
+	if !ok {
+		return nil, false
+	}
+	atomic.StoreInt32(&item.node.visited, 1)
+	tim := time.Now().Unix()
+	item.mutex.Lock()
+	item.lastAccess = tim
+	res := item.data
+	item.mutex.Unlock()
+	return res, true
+}
+
+// Caches the item, does nothing if it already exists
+func (c *PoolInterface) Store(id int, p interface{}, size int64) {
+	ns := c.ns
+	ns.interfaceMutex.Lock()
+	if _, ok := ns.interfaceVals[id]; ok {
+		ns.interfaceMutex.Unlock()
+		return
+	}
+	item := &poolEntryInterface{data: p, lastAccess: time.Now().Unix(), size: size, id: id, ns: ns}
+	item.node.owner = item
+	ns.interfaceVals[id] = item
+	ns.interfaceMutex.Unlock()
+	ns.pool.insert(&item.node)
+	atomic.AddInt64(&ns.pool.mem, size)
+	ns.pool.evict()
+}
+
+// Caches the item, replaces it if it already exists
+func (c *PoolInterface) Replace(id int, p interface{}, size int64) {
+	ns := c.ns
+	tim := time.Now().Unix()
+	ns.interfaceMutex.Lock()
+	item, ok := ns.interfaceVals[id]
+	if !ok {
+		item = &poolEntryInterface{data: p, lastAccess: tim, size: size, id: id, ns: ns}
+		item.node.owner = item
+		ns.interfaceVals[id] = item
+		ns.interfaceMutex.Unlock()
+		ns.pool.insert(&item.node)
+		atomic.AddInt64(&ns.pool.mem, size)
+	} else {
+		ns.interfaceMutex.Unlock()
+		item.mutex.Lock()
+		size -= item.size
+		item.data = p
+		item.lastAccess = tim
+		item.mutex.Unlock()
+		atomic.AddInt64(&ns.pool.mem, size)
+	}
+	ns.pool.evict()
+}
+
+// Delete an entry from the namespace
+func (c *PoolInterface) Remove(id int) {
+	ns := c.ns
+	ns.pool.listMutex.Lock()
+	ns.interfaceMutex.Lock()
+	item, ok := ns.interfaceVals[id]
+	if ok {
+		delete(ns.interfaceVals, id)
+	}
+	ns.interfaceMutex.Unlock()
+	if ok {
+		ns.pool.unlink(&item.node)
+	}
+	ns.pool.listMutex.Unlock()
+	if ok {
+		item.mutex.Lock()
+		size := item.size
+		item.mutex.Unlock()
+		atomic.AddInt64(&ns.pool.mem, 0-size)
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Remove used to delete from ns.bytesVals and unlink the shared SIEVE node under two
+// separate critical sections. If Pool.evict() won the race and unlinked the node first,
+// Remove's own unlink then ran again on a node with stale prev/next pointers, corrupting
+// the shared list. Run concurrent Store/Remove/evict under -race to catch it.
+func TestPoolBytesRemoveRacesWithEvict(t *testing.T) {
+	p := NewPool(1)
+	ns := p.Namespace("ns")
+	c := ns.Bytes()
+
+	const n = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			c.Store(i, make([]byte, 4096))
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			c.Remove(id)
+		}(i)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Store goroutine did not finish; Pool.evict() likely spinning on a corrupted list")
+	}
+	waitWithTimeout(t, &wg, 10*time.Second)
+
+	if atomic.LoadInt64(&p.mem) < 0 {
+		t.Fatalf("p.mem = %d, want >= 0 (double-decremented by a racing Remove/evict)", p.mem)
+	}
+}
+
+// Remove read item.data outside item.mutex while Replace's in-place branch writes it
+// under item.mutex. Run with -race to catch the slice-header race.
+func TestPoolBytesRemoveRacesWithReplace(t *testing.T) {
+	p := NewPool(1)
+	ns := p.Namespace("ns")
+	c := ns.Bytes()
+	c.Store(0, []byte("initial"))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.Replace(0, []byte("replaced"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			c.Remove(0)
+			c.Store(0, []byte("restored"))
+		}
+	}()
+	wg.Wait()
+}
+
+func waitWithTimeout(t *testing.T, wg *sync.WaitGroup, timeout time.Duration) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("goroutines did not finish within timeout")
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of a cache's counters, as returned by Stats()
+type Stats struct {
+	Hits, Misses, Evictions, Expirations, BytesIn, BytesOut int64
+}
+
+// Returns a snapshot of the cache's hit/miss/eviction counters
+func (c *Bytes) Stats() Stats {
+	return Stats{
+		Hits: atomic.LoadInt64(&c.hits),
+		Misses: atomic.LoadInt64(&c.misses),
+		Evictions: atomic.LoadInt64(&c.evictions),
+		Expirations: atomic.LoadInt64(&c.expirations),
+		BytesIn: atomic.LoadInt64(&c.bytesIn),
+		BytesOut: atomic.LoadInt64(&c.bytesOut),
+	}
+}
+
+// Number of entries currently in the cache
+func (c *Bytes) Len() int {
+	n := 0
+	for _, item := range c.vals {
+		if item != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Current memory usage in bytes
+func (c *Bytes) Mem() int64 {
+	return atomic.LoadInt64(&c.mem)
+}
+
+// Memory budget in bytes
+func (c *Bytes) Capacity() int64 {
+	return atomic.LoadInt64(&c.max)
+}
+
+// Publishes Stats() under name via expvar, for scraping by an operator's monitoring stack
+func (c *Bytes) RegisterExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Stats()
+	}))
+}
+
+// Returns a snapshot of the cache's hit/miss/eviction counters
+func (c *Interface) Stats() Stats {
+	return Stats{
+		Hits: atomic.LoadInt64(&c.hits),
+		Misses: atomic.LoadInt64(&c.misses),
+		Evictions: atomic.LoadInt64(&c.evictions),
+		Expirations: atomic.LoadInt64(&c.expirations),
+		BytesIn: atomic.LoadInt64(&c.bytesIn),
+		BytesOut: atomic.LoadInt64(&c.bytesOut),
+	}
+}
+
+// Number of entries currently in the cache
+func (c *Interface) Len() int {
+	n := 0
+	for _, item := range c.vals {
+		if item != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Current memory usage in bytes
+func (c *Interface) Mem() int64 {
+	return atomic.LoadInt64(&c.mem)
+}
+
+// Memory budget (megabytes * 1024), in the units of the sizes passed to Store and Replace
+func (c *Interface) Capacity() int64 {
+	return atomic.LoadInt64(&c.max)
+}
+
+// Publishes Stats() under name via expvar, for scraping by an operator's monitoring stack
+func (c *Interface) RegisterExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Stats()
+	}))
+}
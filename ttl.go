@@ -0,0 +1,242 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Caches the item with an absolute expiration; does nothing if it already exists
+func (c *Bytes) StoreWithTTL(id int, p []byte, ttl time.Duration) {
+	if id >= c.size {
+		return
+	}
+	item := c.vals[id]
+	if item == nil {
+		item = &entryBytes{data: p, lastAccess: time.Now().Unix(), id: id, expiresAt: time.Now().Add(ttl).Unix()}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, int64(len(p)))
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+			c.sieveEvict()
+		}
+	}
+}
+
+// Caches the item with an absolute expiration, replacing it if it already exists
+func (c *Bytes) ReplaceWithTTL(id int, p []byte, ttl time.Duration) {
+	if id >= c.size {
+		return
+	}
+	tim := time.Now().Unix()
+	expiresAt := time.Now().Add(ttl).Unix()
+	item := c.vals[id]
+	if item == nil {
+		item = &entryBytes{data: p, lastAccess: tim, id: id, expiresAt: expiresAt}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, int64(len(p)))
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
+	} else if atomic.LoadInt32(&item.refcount) > 0 {
+		// outstanding Handles may still be reading item.data; detach it instead of mutating
+		// it in place, and give the id a fresh entry so later callers see the new value
+		if c.policy == PolicySIEVE {
+			c.listMutex.Lock()
+			c.sieveUnlink(item)
+			c.listMutex.Unlock()
+		}
+		atomic.AddInt64(&c.mem, 0-int64(len(item.data)))
+		item = &entryBytes{data: p, lastAccess: tim, id: id, expiresAt: expiresAt}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, int64(len(p)))
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
+	} else {
+		item.mutex.Lock()
+		memdif := len(p) - len(item.data)
+		item.data = p
+		item.lastAccess = tim
+		item.expiresAt = expiresAt
+		item.mutex.Unlock()
+		atomic.AddInt64(&c.mem, int64(memdif))
+	}
+	if c.policy == PolicySIEVE {
+		c.sieveEvict()
+	}
+}
+
+// Extends an entry's life without rewriting its payload; does nothing if it doesn't exist
+func (c *Bytes) Touch(id int, ttl time.Duration) {
+	if id >= c.size {
+		return
+	}
+	item := c.vals[id]
+	if item == nil {
+		return
+	}
+	item.mutex.Lock()
+	item.expiresAt = time.Now().Add(ttl).Unix()
+	item.mutex.Unlock()
+}
+
+// dropExpired removes an entry found expired on Get; an entry pinned by an outstanding
+// Handle is deferred the same way Purge/sieveEvict defer a refcounted eviction candidate
+func (c *Bytes) dropExpired(id int, item *entryBytes) {
+	if atomic.LoadInt32(&item.refcount) > 0 {
+		atomic.StoreInt32(&item.marked, 1)
+		return
+	}
+	if c.policy == PolicySIEVE {
+		c.listMutex.Lock()
+		c.sieveUnlink(item)
+		c.listMutex.Unlock()
+	}
+	c.vals[id] = nil
+	item.mutex.Lock()
+	dataLen := len(item.data)
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.mem, 0-int64(dataLen))
+}
+
+// Removes all entries past their TTL, regardless of memory pressure
+func (c *Bytes) purgeExpired() {
+	now := time.Now().Unix()
+	for i, item := range c.vals {
+		if item != nil && item.expiresAt != 0 && now > item.expiresAt {
+			if atomic.LoadInt32(&item.refcount) > 0 {
+				atomic.StoreInt32(&item.marked, 1)
+				continue
+			}
+			item.mutex.Lock()
+			dataLen := len(item.data)
+			item.mutex.Unlock()
+			if c.policy == PolicySIEVE {
+				c.listMutex.Lock()
+				c.sieveUnlink(item)
+				c.listMutex.Unlock()
+			}
+			atomic.AddInt64(&c.mem, 0-int64(dataLen))
+			atomic.AddInt64(&c.expirations, 1)
+			c.vals[i] = nil
+		}
+	}
+}
+
+// Caches the item with an absolute expiration; does nothing if it already exists
+func (c *Interface) StoreWithTTL(id int, p interface{}, size int64, ttl time.Duration) {
+	if id >= c.size {
+		return
+	}
+	item := c.vals[id]
+	if item == nil {
+		item = &entryInterface{data: p, lastAccess: time.Now().Unix(), size: size, id: id, expiresAt: time.Now().Add(ttl).Unix()}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, size)
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+			c.sieveEvict()
+		}
+	}
+}
+
+// Caches the item with an absolute expiration, replacing it if it already exists
+func (c *Interface) ReplaceWithTTL(id int, p []byte, size int64, ttl time.Duration) {
+	if id >= c.size {
+		return
+	}
+	tim := time.Now().Unix()
+	expiresAt := time.Now().Add(ttl).Unix()
+	item := c.vals[id]
+	if item == nil {
+		item = &entryInterface{data: p, lastAccess: tim, size: size, id: id, expiresAt: expiresAt}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, size)
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
+	} else if atomic.LoadInt32(&item.refcount) > 0 {
+		// outstanding Handles may still be reading item.data; detach it instead of mutating
+		// it in place, and give the id a fresh entry so later callers see the new value
+		if c.policy == PolicySIEVE {
+			c.listMutex.Lock()
+			c.sieveUnlink(item)
+			c.listMutex.Unlock()
+		}
+		atomic.AddInt64(&c.mem, 0-item.size)
+		item = &entryInterface{data: p, lastAccess: tim, size: size, id: id, expiresAt: expiresAt}
+		c.vals[id] = item
+		atomic.AddInt64(&c.mem, size)
+		if c.policy == PolicySIEVE {
+			c.sieveInsert(item)
+		}
+	} else {
+		item.mutex.Lock()
+		size -= item.size
+		item.data = p
+		item.lastAccess = tim
+		item.expiresAt = expiresAt
+		item.mutex.Unlock()
+		atomic.AddInt64(&c.mem, size)
+	}
+	if c.policy == PolicySIEVE {
+		c.sieveEvict()
+	}
+}
+
+// Extends an entry's life without rewriting its payload; does nothing if it doesn't exist
+func (c *Interface) Touch(id int, ttl time.Duration) {
+	if id >= c.size {
+		return
+	}
+	item := c.vals[id]
+	if item == nil {
+		return
+	}
+	item.mutex.Lock()
+	item.expiresAt = time.Now().Add(ttl).Unix()
+	item.mutex.Unlock()
+}
+
+// dropExpired removes an entry found expired on Get; an entry pinned by an outstanding
+// Handle is deferred the same way Purge/sieveEvict defer a refcounted eviction candidate
+func (c *Interface) dropExpired(id int, item *entryInterface) {
+	if atomic.LoadInt32(&item.refcount) > 0 {
+		atomic.StoreInt32(&item.marked, 1)
+		return
+	}
+	if c.policy == PolicySIEVE {
+		c.listMutex.Lock()
+		c.sieveUnlink(item)
+		c.listMutex.Unlock()
+	}
+	c.vals[id] = nil
+	item.mutex.Lock()
+	size := item.size
+	item.mutex.Unlock()
+	atomic.AddInt64(&c.mem, 0-size)
+}
+
+// Removes all entries past their TTL, regardless of memory pressure
+func (c *Interface) purgeExpired() {
+	now := time.Now().Unix()
+	for i, item := range c.vals {
+		if item != nil && item.expiresAt != 0 && now > item.expiresAt {
+			if atomic.LoadInt32(&item.refcount) > 0 {
+				atomic.StoreInt32(&item.marked, 1)
+				continue
+			}
+			item.mutex.Lock()
+			size := item.size
+			item.mutex.Unlock()
+			if c.policy == PolicySIEVE {
+				c.listMutex.Lock()
+				c.sieveUnlink(item)
+				c.listMutex.Unlock()
+			}
+			atomic.AddInt64(&c.mem, 0-size)
+			atomic.AddInt64(&c.expirations, 1)
+			c.vals[i] = nil
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// ReplaceWithTTL used to mutate item.data in place unconditionally, even while a Handle
+// held a reference to the old slice, unlike Replace which detaches refcounted entries.
+func TestReplaceWithTTLDetachesRefcountedEntry(t *testing.T) {
+	c := NewBytes(4, 1)
+	c.Store(0, []byte("old"))
+	h, ok := c.GetHandle(0)
+	if !ok {
+		t.Fatal("GetHandle(0) = false, want true")
+	}
+
+	c.ReplaceWithTTL(0, []byte("new"), time.Hour)
+
+	if string(h.Bytes()) != "old" {
+		t.Fatalf("h.Bytes() = %q, want %q (ReplaceWithTTL mutated data out from under the handle)", h.Bytes(), "old")
+	}
+	h.Release()
+
+	got, ok := c.Get(0)
+	if !ok || string(got) != "new" {
+		t.Fatalf("Get(0) = %q, %v; want %q, true", got, ok, "new")
+	}
+}
+
+// dropExpired used to unlink and free a refcounted entry on TTL expiry instead of
+// deferring, like Purge and sieveEvict do, so a handle taken right before expiry had its
+// entry yanked out from under it.
+func TestGetHandleDefersExpiryOfRefcountedEntry(t *testing.T) {
+	c := NewBytesWithPolicy(4, 1, PolicySIEVE)
+	c.StoreWithTTL(0, []byte("expiring"), time.Hour)
+	h, ok := c.GetHandle(0)
+	if !ok {
+		t.Fatal("GetHandle(0) = false, want true")
+	}
+
+	c.vals[0].expiresAt = time.Now().Unix() - 1 // force expiry while the handle is outstanding
+	if _, ok := c.Get(0); ok {
+		t.Fatal("Get(0) on an expired entry = true, want false")
+	}
+
+	h.Release()
+
+	if c.Mem() != 0 {
+		t.Fatalf("Mem() after releasing an expired, refcounted entry = %d, want 0", c.Mem())
+	}
+}